@@ -0,0 +1,174 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import (
+	"encoding/binary"
+
+	"github.com/btcsuite/btcd/btcec"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/ethersphere/feeds/lookup"
+)
+
+// timestampByteLength is the size, in bytes, of a serialized update
+// timestamp.
+const timestampByteLength = 8
+
+// hintFlagLength is the size, in bytes, of the frequency hint's presence
+// flag.
+const hintFlagLength = 1
+
+// chunkHeaderLength is the combined size, in bytes, of a feed update
+// chunk's fixed-size fields: Topic, Epoch, Timestamp, the hint flag and
+// Signature. The optional frequency hint sits between the hint flag and
+// Signature, and the caller's content follows Signature.
+const chunkHeaderLength = TopicLength + epochByteLength + timestampByteLength + hintFlagLength + SignatureLength
+
+// chunkEnvelope holds every field stored in a feed update chunk besides
+// the caller's own content.
+type chunkEnvelope struct {
+	Topic     Topic
+	Epoch     lookup.Epoch
+	Timestamp uint64
+
+	// PrevEpoch is the epoch of the update published immediately before
+	// this one, if any. It lets a reader who only knows this update
+	// jump straight to the previous one without a blind search. It is
+	// optional: HasPrevEpoch is false for a feed's first update, and
+	// chunks that predate this field simply decode with it unset.
+	PrevEpoch    lookup.Epoch
+	HasPrevEpoch bool
+
+	Signature Signature
+}
+
+// addressForEpoch derives the storage address an update for feed would
+// live at, were it published at epoch. The address depends only on the
+// feed's identity and the epoch's slot - Base() and Level, never on
+// epoch.Time itself or the content - which is what lets readers locate
+// updates without needing an index, and what lets two epochs that
+// identify the same slot (see lookup.Epoch) always land on the same
+// address regardless of which Time value either happened to carry.
+func addressForEpoch(feed *Feed, epoch lookup.Epoch) []byte {
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write(feed.Topic[:])
+	hasher.Write(feed.User[:])
+	hasher.Write(encodeEpoch(lookup.Epoch{Time: (&epoch).Base(), Level: epoch.Level}))
+	return hasher.Sum(nil)
+}
+
+// signedSection serializes everything a feed update's Signature signs
+// over: Topic, Epoch, Timestamp, the optional frequency hint and the
+// caller's content, in that order.
+func signedSection(env *chunkEnvelope, data []byte) []byte {
+	buf := make([]byte, 0, chunkHeaderLength-SignatureLength+epochByteLength+len(data))
+	buf = append(buf, env.Topic[:]...)
+	buf = append(buf, encodeEpoch(env.Epoch)...)
+	ts := make([]byte, timestampByteLength)
+	binary.BigEndian.PutUint64(ts, env.Timestamp)
+	buf = append(buf, ts...)
+	if env.HasPrevEpoch {
+		buf = append(buf, 1)
+		buf = append(buf, encodeEpoch(env.PrevEpoch)...)
+	} else {
+		buf = append(buf, 0)
+	}
+	buf = append(buf, data...)
+	return buf
+}
+
+// requestDigest returns the hash that a feed update's Signature signs
+// over.
+func requestDigest(env *chunkEnvelope, data []byte) []byte {
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write(signedSection(env, data))
+	return hasher.Sum(nil)
+}
+
+// encodeChunk assembles env and data into their final on-disk form.
+func encodeChunk(env *chunkEnvelope, data []byte) []byte {
+	signed := signedSection(env, nil)
+	out := make([]byte, 0, len(signed)+SignatureLength+len(data))
+	out = append(out, signed...)
+	out = append(out, env.Signature[:]...)
+	out = append(out, data...)
+	return out
+}
+
+// parseChunk splits a feed update chunk's data back into its envelope
+// and payload.
+func parseChunk(data []byte) (env chunkEnvelope, payload []byte, err error) {
+	if len(data) < TopicLength+epochByteLength+timestampByteLength+hintFlagLength {
+		err = NewError(ErrCorruptData, "update chunk is too short")
+		return
+	}
+	copy(env.Topic[:], data[:TopicLength])
+	offset := TopicLength
+
+	env.Epoch, err = decodeEpoch(data[offset : offset+epochByteLength])
+	if err != nil {
+		return
+	}
+	offset += epochByteLength
+
+	env.Timestamp = binary.BigEndian.Uint64(data[offset : offset+timestampByteLength])
+	offset += timestampByteLength
+
+	hasHint := data[offset] != 0
+	offset += hintFlagLength
+	if hasHint {
+		if len(data) < offset+epochByteLength {
+			err = NewError(ErrCorruptData, "update chunk's frequency hint is truncated")
+			return
+		}
+		env.PrevEpoch, err = decodeEpoch(data[offset : offset+epochByteLength])
+		if err != nil {
+			return
+		}
+		env.HasPrevEpoch = true
+		offset += epochByteLength
+	}
+
+	if len(data) < offset+SignatureLength {
+		err = NewError(ErrCorruptData, "update chunk is missing its signature")
+		return
+	}
+	copy(env.Signature[:], data[offset:offset+SignatureLength])
+	offset += SignatureLength
+
+	payload = data[offset:]
+	return
+}
+
+// recoverAddress recovers the Ethereum address of the account that
+// produced signature over digest.
+func recoverAddress(signature Signature, digest []byte) (EthereumAddress, error) {
+	var address EthereumAddress
+
+	// Signature is laid out [r(32) | s(32) | recovery id(1)]; btcec
+	// wants the recovery id (offset by 27, as Ethereum does) first.
+	compact := make([]byte, SignatureLength)
+	compact[0] = signature[SignatureLength-1] + 27
+	copy(compact[1:], signature[:SignatureLength-1])
+
+	pubKey, _, err := btcec.RecoverCompact(btcec.S256(), compact, digest)
+	if err != nil {
+		return address, NewError(ErrInvalidSignature, "could not recover signer: "+err.Error())
+	}
+	return ethereumAddress(pubKey.ToECDSA()), nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import "fmt"
+
+// Error codes returned by NewError. Callers that need to distinguish
+// failure modes (for example "not found" vs "invalid signature") should
+// compare against these rather than matching on message text.
+const (
+	ErrInvalidValue = iota
+	ErrInvalidSignature
+	ErrNotFound
+	ErrIO
+	ErrUnauthorized
+	ErrDataOverflow
+	ErrNothingToReturn
+	ErrCorruptData
+	ErrInvalidInput
+)
+
+// Error is the error type used throughout the feed package. It pairs a
+// stable Code (for programmatic handling) with a human-readable message.
+type Error struct {
+	Code    int
+	message string
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return e.message
+}
+
+// NewError creates an Error with the given code and message.
+func NewError(code int, s string) error {
+	if code < ErrInvalidValue || code > ErrInvalidInput {
+		panic("invalid error code")
+	}
+	return &Error{
+		Code:    code,
+		message: s,
+	}
+}
+
+// NewErrorf is like NewError but formats its message like fmt.Sprintf.
+func NewErrorf(code int, format string, args ...interface{}) error {
+	return NewError(code, fmt.Sprintf(format, args...))
+}
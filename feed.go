@@ -0,0 +1,48 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package feed implements Swarm feeds: mutable, versioned streams of
+// content published by an Ethereum account under a Topic, addressable
+// without a mutable index by deriving each update's storage address from
+// (User, Topic, Epoch).
+package feed
+
+import (
+	"encoding/hex"
+)
+
+// Feed identifies a single feed: a stream of updates published by User
+// under Topic. Two users can publish independent feeds under the same
+// Topic, and the same user can run any number of feeds distinguished by
+// Topic alone.
+type Feed struct {
+	Topic Topic
+	User  EthereumAddress
+}
+
+// FeedKey returns the byte string used to derive update storage
+// addresses for this feed: Topic and User concatenated, in that order.
+func (f *Feed) FeedKey() []byte {
+	key := make([]byte, 0, len(f.Topic)+len(f.User))
+	key = append(key, f.Topic[:]...)
+	key = append(key, f.User[:]...)
+	return key
+}
+
+// Hex returns a human-readable identifier for the feed.
+func (f *Feed) Hex() string {
+	return hex.EncodeToString(f.FeedKey())
+}
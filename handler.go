@@ -0,0 +1,417 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethersphere/feeds/lookup"
+)
+
+// HandlerParams configures a Handler. The zero value is a valid,
+// conservative default.
+type HandlerParams struct {
+	// WatchMinInterval bounds how fast Watch will re-poll a busy feed.
+	// Zero means defaultWatchMinInterval.
+	WatchMinInterval time.Duration
+	// WatchMaxInterval bounds how slowly Watch will re-poll a quiet
+	// feed. Zero means defaultWatchMaxInterval.
+	WatchMaxInterval time.Duration
+
+	// LookupBatchWorkers bounds how many queries LookupBatch searches
+	// concurrently. Zero means defaultLookupBatchWorkers.
+	LookupBatchWorkers int
+}
+
+// Handler reads and writes feed updates through a LoadSaver. It is safe
+// for concurrent use.
+type Handler struct {
+	params *HandlerParams
+
+	mu    sync.RWMutex
+	ls    LoadSaver
+	cache map[string]*Update
+
+	// latest holds, per feed, the newest update this Handler is certain
+	// of: one it has itself published via Update, or one that
+	// LookupWithFreshHint has fetched through its own NewQueryLatest
+	// lookup. Unlike cache, a lookup for an
+	// arbitrary historical time never writes here, so it can never be
+	// mistaken for the feed's actual latest update (see
+	// LookupWithFreshHint).
+	latest map[string]*Update
+}
+
+// NewHandler creates a Handler. A nil params uses the zero value.
+func NewHandler(params *HandlerParams) *Handler {
+	if params == nil {
+		params = &HandlerParams{}
+	}
+	return &Handler{
+		params: params,
+		cache:  make(map[string]*Update),
+		latest: make(map[string]*Update),
+	}
+}
+
+// SetLoadSaver installs the storage backend updates are read from and
+// written to.
+func (h *Handler) SetLoadSaver(ls LoadSaver) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ls = ls
+}
+
+func (h *Handler) loadSaver() (LoadSaver, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.ls == nil {
+		return nil, NewError(ErrInvalidInput, "handler has no LoadSaver configured")
+	}
+	return h.ls, nil
+}
+
+func (h *Handler) watchMinInterval() time.Duration {
+	if h.params.WatchMinInterval > 0 {
+		return h.params.WatchMinInterval
+	}
+	return defaultWatchMinInterval
+}
+
+func (h *Handler) watchMaxInterval() time.Duration {
+	if h.params.WatchMaxInterval > 0 {
+		return h.params.WatchMaxInterval
+	}
+	return defaultWatchMaxInterval
+}
+
+func (h *Handler) lookupBatchWorkers() int {
+	if h.params.LookupBatchWorkers > 0 {
+		return h.params.LookupBatchWorkers
+	}
+	return defaultLookupBatchWorkers
+}
+
+// NewRequest prepares the Request for the next update to feed: it looks
+// up the latest known update (if any) and works out the epoch the new
+// update should be published at.
+func (h *Handler) NewRequest(ctx context.Context, feed *Feed) (*Request, error) {
+	now := TimestampProvider.Now().Time
+
+	var last lookup.Epoch
+	latest, err := h.Lookup(ctx, NewQueryLatest(feed, lookup.NoClue))
+	switch {
+	case err == nil:
+		last = latest.Epoch
+	case isNotFound(err):
+		// no previous update: last stays the zero Epoch, and
+		// GetNextEpoch treats that as "this is the first update".
+	default:
+		return nil, err
+	}
+
+	request := &Request{Feed: *feed}
+	request.Epoch = lookup.GetNextEpoch(last, now)
+	request.Timestamp = now
+	if err == nil {
+		request.PrevEpoch = last
+		request.HasPrevEpoch = true
+	}
+	return request, nil
+}
+
+// Update publishes request, failing if an update already exists at its
+// epoch.
+func (h *Handler) Update(ctx context.Context, request *Request) ([]byte, error) {
+	ls, err := h.loadSaver()
+	if err != nil {
+		return nil, err
+	}
+
+	addr, data, err := request.toChunk()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := ls.Load(ctx, addr); err == nil {
+		return nil, NewError(ErrInvalidInput, "an update already exists at this epoch")
+	}
+
+	if err := ls.Save(ctx, addr, data); err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	update := request.Update
+	h.cache[request.Feed.Hex()] = &update
+	h.latest[request.Feed.Hex()] = &update
+	h.mu.Unlock()
+
+	return addr, nil
+}
+
+// Lookup finds the update matching query. If query identifies its feed
+// indirectly, via ManifestRef rather than Feed, Lookup resolves the
+// reference itself before searching - callers who only have a manifest
+// reference never need to call ResolveManifest by hand.
+func (h *Handler) Lookup(ctx context.Context, query *Query) (*Update, error) {
+	ls, err := h.loadSaver()
+	if err != nil {
+		return nil, err
+	}
+	query, err = h.resolveQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return h.lookup(ctx, query, ls)
+}
+
+// resolveQuery returns query unchanged unless it identifies its feed via
+// ManifestRef, in which case it returns a copy with Feed filled in from
+// the resolved manifest and ManifestRef cleared.
+func (h *Handler) resolveQuery(ctx context.Context, query *Query) (*Query, error) {
+	if query.ManifestRef == nil {
+		return query, nil
+	}
+	f, _, err := h.ResolveManifest(ctx, query.ManifestRef)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *query
+	resolved.Feed = *f
+	resolved.ManifestRef = nil
+	return &resolved, nil
+}
+
+// lookup is Lookup's implementation, taking its LoadSaver explicitly so
+// LookupBatch can run many lookups concurrently against a single shared,
+// deduplicating LoadSaver instead of each going through h.loadSaver().
+func (h *Handler) lookup(ctx context.Context, query *Query, ls LoadSaver) (*Update, error) {
+	read := func(ctx context.Context, epoch lookup.Epoch, now uint64) (interface{}, error) {
+		addr := addressForEpoch(&query.Feed, epoch)
+		raw, err := ls.Load(ctx, addr)
+		if err != nil {
+			return nil, lookup.ErrNotFound
+		}
+		update, err := parseUpdate(raw)
+		if err != nil {
+			return nil, err
+		}
+		if update.Timestamp > now || update.Epoch.Time > now {
+			// the chunk exists, but its content postdates the
+			// query. Epoch.Time is checked too, not just
+			// Timestamp: a nested refine candidate can land on a
+			// slot that genuinely contains a later update even
+			// though its Base() doesn't exceed now, since a
+			// coarse epoch's window can span both the queried
+			// instant and updates published well after it.
+			return nil, lookup.ErrFutureUpdate
+		}
+		return update, nil
+	}
+
+	result, err := lookup.Lookup(ctx, query.TimeLimit, query.Hint, read)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, NewError(ErrNotFound, "no update found for this feed at or before the requested time")
+	}
+	update, ok := result.(*Update)
+	if !ok || update == nil {
+		return nil, NewError(ErrNotFound, "no update found for this feed at or before the requested time")
+	}
+
+	h.mu.Lock()
+	h.cache[query.Feed.Hex()] = update
+	h.mu.Unlock()
+
+	return update, nil
+}
+
+// LookupBatch answers every query in qs concurrently, bounded by
+// HandlerParams.LookupBatchWorkers, and returns index-aligned results and
+// errors: results[i] and errs[i] answer qs[i]. A failing query only sets
+// its own errs[i] and never aborts the rest of the batch.
+//
+// All queries share a single deduplicating LoadSaver, so when two
+// queries' epoch walks ask for the same chunk while it is still being
+// fetched - common for feeds queried around the same time limit - that
+// fetch is shared. A request for a chunk whose fetch has already
+// finished fetches it again.
+// Cancelling ctx aborts whatever queries are still outstanding.
+func (h *Handler) LookupBatch(ctx context.Context, qs []*Query) ([]*Update, []error) {
+	results := make([]*Update, len(qs))
+	errs := make([]error, len(qs))
+
+	ls, err := h.loadSaver()
+	if err != nil {
+		for i := range qs {
+			errs[i] = err
+		}
+		return results, errs
+	}
+	dedup := newDedupLoadSaver(ls)
+
+	sem := make(chan struct{}, h.lookupBatchWorkers())
+	var wg sync.WaitGroup
+	for i, query := range qs {
+		i, query := i, query
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			resolved, err := h.resolveQuery(ctx, query)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			results[i], errs[i] = h.lookup(ctx, resolved, dedup)
+		}()
+	}
+	wg.Wait()
+
+	return results, errs
+}
+
+// LookupWithFreshHint answers q the way Lookup does, but accelerates the
+// search by first fetching the feed's latest update - via the dedicated
+// latest-update cache if Update or an earlier LookupWithFreshHint call
+// already recorded it, otherwise via the same NewQueryLatest path
+// NewRequest uses - and reading the frequency hint embedded in it (see
+// Update.PrevEpoch): a pointer to the epoch of the update published just
+// before it.
+//
+// Deliberately not reused here: Handler's general per-feed cache. It is
+// written by every Lookup call, including ones for an arbitrary
+// historical TimeLimit, so treating whatever it holds as "the latest
+// update" would seed the search from a stale or unrelated result anytime
+// a caller had already looked up that feed's past.
+//
+// On a sparsely updated feed the fresh hint's pointer lands
+// lookup.Lookup's initial guess right next to a real, existing epoch
+// instead of the coarse blind guess q.Hint (or lookup.NoClue) would have
+// produced, turning what would otherwise be a slow climb through empty
+// epochs into a search that converges in roughly one hop. It is purely
+// an optimization: when the latest update's Timestamp is at or before
+// q.TimeLimit, that update's own Epoch is the hint; otherwise its
+// PrevEpoch is, and a latest update without one (it predates the hint
+// field, or is the feed's only update) leaves q.Hint unchanged.
+func (h *Handler) LookupWithFreshHint(ctx context.Context, q *Query) (*Update, error) {
+	q, err := h.resolveQuery(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, ok := h.cachedLatest(&q.Feed)
+	if !ok {
+		latest, err = h.Lookup(ctx, NewQueryLatest(&q.Feed, lookup.NoClue))
+		if err != nil {
+			return nil, err
+		}
+		h.setCachedLatest(&q.Feed, latest)
+	}
+
+	accelerated := *q
+	switch {
+	case latest.Timestamp <= q.TimeLimit:
+		// the latest update already satisfies the query: reuse its
+		// own epoch as the tightest possible hint.
+		accelerated.Hint = latest.Epoch
+	case latest.HasPrevEpoch:
+		accelerated.Hint = latest.PrevEpoch
+	}
+	return h.Lookup(ctx, &accelerated)
+}
+
+// cachedLatest returns the feed's confirmed latest update, if Update or a
+// prior LookupWithFreshHint call has recorded one, without touching
+// storage.
+func (h *Handler) cachedLatest(feed *Feed) (*Update, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	update, ok := h.latest[feed.Hex()]
+	return update, ok
+}
+
+// setCachedLatest records update as feed's confirmed latest update.
+func (h *Handler) setCachedLatest(feed *Feed, update *Update) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.latest[feed.Hex()] = update
+}
+
+// GetContent returns the update for feed most recently published via
+// Update or found by a lookup, without going back to storage.
+func (h *Handler) GetContent(feed *Feed) (*Update, []byte, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	update, ok := h.cache[feed.Hex()]
+	if !ok {
+		return nil, nil, NewError(ErrNothingToReturn, "no update has been looked up for this feed yet")
+	}
+	return update, update.data, nil
+}
+
+// Validate reports whether data is a well-formed, correctly signed feed
+// update chunk whose derived address matches addr.
+func (h *Handler) Validate(addr []byte, data []byte) bool {
+	env, payload, err := parseChunk(data)
+	if err != nil {
+		return false
+	}
+
+	digest := requestDigest(&env, payload)
+	user, err := recoverAddress(env.Signature, digest)
+	if err != nil {
+		return false
+	}
+
+	feed := Feed{Topic: env.Topic, User: user}
+	return bytes.Equal(addr, addressForEpoch(&feed, env.Epoch))
+}
+
+func parseUpdate(raw []byte) (*Update, error) {
+	env, payload, err := parseChunk(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &Update{
+		Epoch:        env.Epoch,
+		Timestamp:    env.Timestamp,
+		PrevEpoch:    env.PrevEpoch,
+		HasPrevEpoch: env.HasPrevEpoch,
+		data:         payload,
+	}, nil
+}
+
+func isNotFound(err error) bool {
+	feedErr, ok := err.(*Error)
+	return ok && feedErr.Code == ErrNotFound
+}
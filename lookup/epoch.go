@@ -0,0 +1,146 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package lookup
+
+import "fmt"
+
+// HighestLevel is the Epoch level a brand new feed's first update is
+// published at (see GetFirstEpoch). Larger levels cover longer timespans
+// (an epoch at level L spans 2^L seconds); later updates can land above
+// HighestLevel, since GetNextEpoch climbs past it to bridge a long gap and
+// reflects above it after maxRefinementDepth refinements.
+const HighestLevel = 25
+
+// LowestLevel is the most granular Epoch resolution: every second is its own
+// epoch.
+const LowestLevel = 0
+
+// Epoch identifies a slot in the update tree: a span of 2^Level seconds
+// starting at Base(). Two epochs with the same Base() and Level refer to the
+// exact same slot, and an update published there can never be overwritten,
+// only superseded by an update in a different epoch.
+type Epoch struct {
+	Time  uint64
+	Level uint8
+}
+
+// NoClue is the zero-value Epoch. Passing it as a lookup hint tells Lookup
+// it has no information to accelerate the search and must walk the tree
+// blind, starting at HighestLevel.
+var NoClue = Epoch{}
+
+// Base returns the start time of the epoch, i.e. Time rounded down to a
+// multiple of 2^Level.
+func (e *Epoch) Base() uint64 {
+	return getBaseTime(e.Time, e.Level)
+}
+
+// Equals returns true if both epochs identify the same slot.
+func (e *Epoch) Equals(other Epoch) bool {
+	return e.Base() == other.Base() && e.Level == other.Level
+}
+
+// String implements the Stringer interface.
+func (e Epoch) String() string {
+	return fmt.Sprintf("Epoch{Time:%d, Level:%d, Base:%d}", e.Time, e.Level, (&e).Base())
+}
+
+func getBaseTime(t uint64, level uint8) uint64 {
+	return t &^ ((1 << level) - 1)
+}
+
+// GetFirstEpoch returns the epoch a brand new feed's very first update
+// should be published at: the coarsest possible slot containing t, since
+// nothing is yet known about the feed's update frequency. Time keeps t
+// itself rather than the slot's rounded-down Base(), so a reader walking
+// back from the found chunk's own Epoch can tell exactly when it was
+// published, not just which coarse window it fell in.
+func GetFirstEpoch(t uint64) Epoch {
+	return Epoch{
+		Time:  t,
+		Level: HighestLevel,
+	}
+}
+
+// GetNextEpoch works out which epoch a new update at time t should be
+// published at, given the epoch of the last known update of the feed.
+//
+// Where possible it refines on the previous epoch by one level, keeping
+// the update inside the same base window so that a reader who only knows
+// the previous epoch can find the new one with a single extra step. If t
+// has drifted outside that window, it climbs back up until it finds a
+// level whose base is shared by both the last epoch and t.
+func GetNextEpoch(last Epoch, t uint64) Epoch {
+	if last == (Epoch{}) {
+		return GetFirstEpoch(t)
+	}
+	return getNextEpoch(last, t)
+}
+
+// maxRefinementDepth bounds how many consecutive refinements GetNextEpoch
+// will nest below HighestLevel before reflecting back out above it. A feed
+// updated far more often than its own history would suggest keeps landing
+// inside the very same coarse window, so naive nesting would nibble its way
+// down toward LowestLevel one update at a time - each new epoch only a
+// single refine-step away from the last, never any coarser, however many
+// bursts of rapid updates pile up. Capping the descent and reflecting back
+// out keeps a long burst's epochs spread across a span a blind search -
+// which only ever climbs past HighestLevel, never refines past LowestLevel
+// looking for something finer than what it already widened from - can
+// still reach.
+const maxRefinementDepth = 2
+
+func getNextEpoch(last Epoch, t uint64) Epoch {
+	if last.Level == LowestLevel {
+		return Epoch{Time: t, Level: LowestLevel}
+	}
+
+	candidateLevel := last.Level - 1
+	candidateBase := getBaseTime(t, candidateLevel)
+	lastBase := last.Base()
+	if candidateBase >= lastBase && candidateBase < lastBase+(1<<last.Level) {
+		// t is still somewhere inside the window last occupied - either
+		// half of it, not just the half starting at lastBase - so it can
+		// be addressed by a strictly finer, previously untried epoch
+		// nested under last.
+		if candidateLevel <= HighestLevel && HighestLevel-candidateLevel > maxRefinementDepth {
+			// Nesting any further would exceed the refinement budget.
+			// Reflect the level back out above HighestLevel by the same
+			// distance it would otherwise have dropped below it, so the
+			// chain keeps climbing into fresh territory instead of
+			// continuing to burrow toward LowestLevel.
+			return Epoch{Time: t, Level: HighestLevel + (HighestLevel - candidateLevel)}
+		}
+		return Epoch{Time: t, Level: candidateLevel}
+	}
+
+	// t has escaped the window last was nested in entirely,
+	// so precision cannot be increased. Climb the tree - past
+	// HighestLevel if need be, since a large enough gap from last can
+	// demand it - until both the last update and t fall under the same
+	// base. The climb must start strictly above last.Level: stopping at
+	// last.Level itself would only be possible if t and lastBase already
+	// shared that base, in which case the result would equal last
+	// exactly - the very epoch already occupied - instead of a genuinely
+	// new one. Lookup's blind widen climbs past HighestLevel the same
+	// way to stay able to find whatever this produces.
+	level := last.Level + 1
+	for getBaseTime(t, level) != getBaseTime(lastBase, level) {
+		level++
+	}
+	return Epoch{Time: t, Level: level}
+}
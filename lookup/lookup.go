@@ -0,0 +1,211 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package lookup implements the epoch-based search algorithm used to
+// locate feed updates without needing an index: given nothing more than
+// the feed's topic and owner, a reader descends a tree of candidate
+// epochs until it finds the most recent update at or before a target
+// time.
+package lookup
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned by a ReadFunc when no update exists at the
+// requested epoch.
+var ErrNotFound = errors.New("no update found at this epoch")
+
+// ErrFutureUpdate is returned by a ReadFunc when an update exists at the
+// requested epoch but its content postdates now. Lookup treats it exactly
+// like ErrNotFound: whether nothing lives at this epoch or something
+// does but cannot qualify, there is no usable answer here, and - since an
+// ancestor of this very epoch can still turn out to hold a qualifying
+// update (a coarser epoch's window contains its descendants' windows
+// too) - the search keeps widening past it rather than descending into
+// it, exactly as it would past a genuinely empty epoch.
+var ErrFutureUpdate = errors.New("update at this epoch postdates the query")
+
+// ReadFunc attempts to read the update published at epoch, given the
+// caller's upper time bound now. Implementations should return
+// ErrNotFound (or a wrapped form of it) when the epoch is empty so that
+// Lookup can keep searching.
+type ReadFunc func(ctx context.Context, epoch Epoch, now uint64) (interface{}, error)
+
+// Lookup walks the epoch tree looking for the most recent update at or
+// before now, using hint as a starting point. A zero-value hint (NoClue)
+// makes the search start from GetFirstEpoch(now), at HighestLevel.
+//
+// The algorithm has two distinct phases. Until it finds a first update it
+// widens - climbing to coarser and coarser epochs - since nothing is
+// known yet about where any update might live. Once it has found one, it
+// only ever refines from there, trying one finer candidate epoch nested
+// inside the one just found; a writer only ever publishes at the exact
+// epoch GetNextEpoch computed, so if that one finer candidate is empty,
+// there is no other address a more recent update could be at, and the
+// update already found is the answer. Widening again at that point would
+// just recompute the very epoch refine stepped away from and spin
+// forever, so Lookup deliberately never does it once something has been
+// found.
+func Lookup(ctx context.Context, now uint64, hint Epoch, read ReadFunc) (interface{}, error) {
+	if hint == (Epoch{}) {
+		hint = GetFirstEpoch(now)
+	}
+
+	var (
+		last    interface{}
+		lastErr = ErrNotFound
+		epoch   = hint
+		// scannedBase is the base already swept level by level below.
+		// Widening climbs by raising the level at the base now itself
+		// falls in, so it only ever revisits that one base's lineage; it
+		// would never stumble back onto a coarser window's base at a
+		// finer level even though an update is sitting right there in
+		// the very same coarser window. The branch scan below plugs that
+		// gap.
+		scannedBase = hint.Base()
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return last, ctx.Err()
+		default:
+		}
+
+		if epoch.Base() > now {
+			// an externally supplied hint can point at an epoch that
+			// postdates now (e.g. a frequency hint recorded by an
+			// update more recent than this query's TimeLimit); such an
+			// epoch can never hold the answer, so treat it as empty
+			// without even trying to read it.
+			wider, ok := widen(epoch, now)
+			if !ok {
+				return last, lastErr
+			}
+			epoch = wider
+			continue
+		}
+
+		value, err := read(ctx, epoch, now)
+		if err == nil {
+			last, lastErr = value, nil
+			next, ok := refine(epoch, now)
+			if !ok {
+				return last, nil
+			}
+			epoch = next
+			continue
+		}
+		if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrFutureUpdate) {
+			return last, err
+		}
+		if last != nil {
+			// the one finer candidate nested under the update we
+			// already found came up empty (or postdates now): that
+			// update is the answer.
+			return last, nil
+		}
+
+		if epoch.Level > LowestLevel && epoch.Base() != scannedBase {
+			// widen just moved into a coarser window whose base has never
+			// been swept below: any update nested anywhere under this
+			// base - at any level from here down to LowestLevel, not just
+			// HighestLevel where a feed's genesis update always lives -
+			// would otherwise never be looked at again, since widening
+			// only ever climbs back through the branch now itself falls
+			// in. Sweep from the level just below epoch down to
+			// LowestLevel, stopping at the first (coarsest, so earliest)
+			// update found; every write under a shared base that refine
+			// would reach from there is still picked up afterwards by the
+			// usual single-step descent below.
+			base := epoch.Base()
+			scannedBase = base
+			for level := int(epoch.Level) - 1; level >= LowestLevel; level-- {
+				candidate := Epoch{Time: base, Level: uint8(level)}
+				cvalue, cerr := read(ctx, candidate, now)
+				if cerr == nil {
+					last, lastErr = cvalue, nil
+					next, ok := refine(candidate, now)
+					if !ok {
+						return last, nil
+					}
+					epoch = next
+					break
+				}
+				if !errors.Is(cerr, ErrNotFound) && !errors.Is(cerr, ErrFutureUpdate) {
+					return last, cerr
+				}
+			}
+			if last != nil {
+				continue
+			}
+		}
+
+		wider, ok := widen(epoch, now)
+		if !ok {
+			return last, lastErr
+		}
+		epoch = wider
+	}
+}
+
+// refine proposes the narrower epoch nested within the one already known
+// to contain an update: the second half of its window, unless that half
+// postdates now, in which case the first half (epoch's own base) is tried
+// instead. It returns ok=false once epoch is already at LowestLevel.
+//
+// If nesting one level deeper would exceed maxRefinementDepth, it instead
+// proposes the same reflected, coarser-than-HighestLevel epoch that
+// getNextEpoch would have published a burst of rapid updates at (see
+// lookup/epoch.go) - the two must stay in lock-step, since refine only
+// ever gets one guess at the epoch nested under the one it already found.
+func refine(epoch Epoch, now uint64) (Epoch, bool) {
+	if epoch.Level == LowestLevel {
+		return Epoch{}, false
+	}
+	level := epoch.Level - 1
+	if level <= HighestLevel && HighestLevel-level > maxRefinementDepth {
+		return Epoch{Time: now, Level: HighestLevel + (HighestLevel - level)}, true
+	}
+	secondHalf := epoch.Base() + (1 << level)
+	if secondHalf > now {
+		return Epoch{Time: epoch.Base(), Level: level}, true
+	}
+	return Epoch{Time: secondHalf, Level: level}, true
+}
+
+// maxLevel bounds how far widen will climb: once a window spans the
+// entire uint64 time range, climbing further can never change which
+// update it covers, so there is nothing left to try.
+const maxLevel = 64
+
+// widen proposes a coarser epoch covering the same point in time, used
+// when the current candidate came up empty. HighestLevel is only where a
+// brand new feed's first update is published (see GetFirstEpoch); a
+// sparsely updated feed's GetNextEpoch can climb past it to stay linked
+// to its history (see lookup/epoch.go), so a blind search must be able
+// to climb past it too, or it could never find such an update. It
+// returns ok=false once epoch is already at maxLevel, meaning the feed
+// has no updates at or before now.
+func widen(epoch Epoch, now uint64) (Epoch, bool) {
+	if epoch.Level >= maxLevel {
+		return Epoch{}, false
+	}
+	level := epoch.Level + 1
+	return Epoch{Time: getBaseTime(now, level), Level: level}, true
+}
@@ -0,0 +1,178 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ethersphere/feeds/lookup"
+)
+
+// countingLoadSaver wraps a LoadSaver and counts how many times Load is
+// called, so tests can compare how many chunk fetches two lookup
+// strategies need for the same query.
+type countingLoadSaver struct {
+	inner LoadSaver
+	loads int
+}
+
+func (c *countingLoadSaver) Load(ctx context.Context, addr []byte) ([]byte, error) {
+	c.loads++
+	return c.inner.Load(ctx, addr)
+}
+
+func (c *countingLoadSaver) Save(ctx context.Context, addr []byte, data []byte) error {
+	return c.inner.Save(ctx, addr, data)
+}
+
+// TestLookupWithFreshHint replays TestSparseUpdates' write pattern (one
+// update every 5 years) and confirms that answering the same historical
+// query via LookupWithFreshHint costs strictly fewer chunk fetches than
+// the blind lookup.NoClue path Lookup takes on its own.
+func TestLookupWithFreshHint(t *testing.T) {
+	timeProvider := &fakeTimeProvider{
+		currentTime: startTime.Time,
+	}
+	signer := newAliceSigner()
+
+	rh, _, teardownTest, err := setupTest(timeProvider, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardownTest()
+
+	ls := newMockLoadSaver()
+	rh.SetLoadSaver(ls)
+
+	ctx := context.Background()
+	topic, _ := NewTopic("Very slow updates, with hints", nil)
+	a, err := signer.EthereumAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd := Feed{Topic: topic}
+	copy(fd.User[:], a.Bytes())
+
+	today := uint64(1533799046)
+	var epoch lookup.Epoch
+	for T := uint64(0); T < today; T += 5 * Year {
+		request := NewFirstRequest(fd.Topic)
+		request.Epoch = lookup.GetNextEpoch(epoch, T)
+		if epoch != (lookup.Epoch{}) {
+			request.PrevEpoch = epoch
+			request.HasPrevEpoch = true
+		}
+		request.SetData(generateData(T))
+		if err := request.Sign(signer); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := rh.Update(ctx, request); err != nil {
+			t.Fatal(err)
+		}
+		epoch = request.Epoch
+	}
+
+	timeLimit := uint64(35*Year + 6*Month)
+
+	// A handler with no cache of its own has to find the query's answer
+	// by blind search alone, same as plain Lookup always does.
+	blindLS := &countingLoadSaver{inner: ls}
+	blindHandler := NewHandler(&HandlerParams{})
+	blindHandler.SetLoadSaver(blindLS)
+	blindUpdate, err := blindHandler.Lookup(ctx, NewQuery(&fd, timeLimit, lookup.NoClue))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// rh, in contrast, still has the last update it wrote cached, so
+	// LookupWithFreshHint can seed a much tighter search from it without
+	// any extra fetches. Every update here carries NewFirstRequest's
+	// Timestamp of 4200, at or before timeLimit, so the seed is that
+	// update's own Epoch rather than its PrevEpoch.
+	hintedLS := &countingLoadSaver{inner: ls}
+	rh.SetLoadSaver(hintedLS)
+	hintedUpdate, err := rh.LookupWithFreshHint(ctx, NewQuery(&fd, timeLimit, lookup.NoClue))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(blindUpdate.Data(), hintedUpdate.Data()) {
+		t.Fatalf("blind and hinted lookups disagreed: %q vs %q", blindUpdate.Data(), hintedUpdate.Data())
+	}
+	if !bytes.Equal(hintedUpdate.Data(), generateData(35*Year)) {
+		t.Fatalf("expected %d, got %s", 35*Year, hintedUpdate.Data())
+	}
+
+	if hintedLS.loads >= blindLS.loads {
+		t.Fatalf("expected LookupWithFreshHint to need fewer Load calls than a blind lookup, got %d hinted vs %d blind", hintedLS.loads, blindLS.loads)
+	}
+}
+
+// TestLookupWithFreshHintIgnoresHistoricalLookups confirms that a
+// historical (non-latest) Lookup on a feed doesn't get mistaken for that
+// feed's latest update by a later LookupWithFreshHint call.
+func TestLookupWithFreshHintIgnoresHistoricalLookups(t *testing.T) {
+	timeProvider := &fakeTimeProvider{currentTime: startTime.Time}
+	signer := newAliceSigner()
+
+	h, _, teardownTest, err := setupTest(timeProvider, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardownTest()
+	h.SetLoadSaver(newMockLoadSaver())
+
+	ctx := context.Background()
+	topic, _ := NewTopic("Stale cache regression", nil)
+
+	var epoch lookup.Epoch
+	var last *Request
+	for _, T := range []uint64{0, 10 * Year, 20 * Year} {
+		request := NewFirstRequest(topic)
+		request.Epoch = lookup.GetNextEpoch(epoch, T)
+		if epoch != (lookup.Epoch{}) {
+			request.PrevEpoch = epoch
+			request.HasPrevEpoch = true
+		}
+		request.SetData(generateData(T))
+		if err := request.Sign(signer); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := h.Update(ctx, request); err != nil {
+			t.Fatal(err)
+		}
+		epoch = request.Epoch
+		last = request
+	}
+	fd := last.Feed
+
+	// Look up a point in the feed's past - this must not be mistaken for
+	// "the latest update" by a later LookupWithFreshHint call.
+	if _, err := h.Lookup(ctx, NewQuery(&fd, 5*Year, lookup.NoClue)); err != nil {
+		t.Fatal(err)
+	}
+
+	update, err := h.LookupWithFreshHint(ctx, NewQuery(&fd, 20*Year, lookup.NoClue))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(update.Data(), generateData(20*Year)) {
+		t.Fatalf("LookupWithFreshHint returned %q after an intervening historical Lookup, want %q", update.Data(), generateData(20*Year))
+	}
+}
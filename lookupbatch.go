@@ -0,0 +1,83 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import (
+	"context"
+	"sync"
+)
+
+// defaultLookupBatchWorkers bounds how many queries LookupBatch searches
+// concurrently when HandlerParams.LookupBatchWorkers is unset.
+const defaultLookupBatchWorkers = 8
+
+// dedupLoadSaver wraps a LoadSaver so that concurrent Load calls for the
+// same address share a single underlying fetch: the first caller does the
+// real Load, and every other caller for that address while it is in
+// flight waits on the same result instead of issuing its own.
+type dedupLoadSaver struct {
+	inner LoadSaver
+
+	mu      sync.Mutex
+	pending map[string]*dedupCall
+}
+
+// dedupCall is the in-flight (or just-finished) state of a single Load
+// call, shared by every goroutine asking for the same address.
+type dedupCall struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+func newDedupLoadSaver(inner LoadSaver) *dedupLoadSaver {
+	return &dedupLoadSaver{
+		inner:   inner,
+		pending: make(map[string]*dedupCall),
+	}
+}
+
+func (d *dedupLoadSaver) Load(ctx context.Context, addr []byte) ([]byte, error) {
+	key := string(addr)
+
+	d.mu.Lock()
+	if call, ok := d.pending[key]; ok {
+		d.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.data, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call := &dedupCall{done: make(chan struct{})}
+	d.pending[key] = call
+	d.mu.Unlock()
+
+	call.data, call.err = d.inner.Load(ctx, addr)
+	close(call.done)
+
+	d.mu.Lock()
+	delete(d.pending, key)
+	d.mu.Unlock()
+
+	return call.data, call.err
+}
+
+func (d *dedupLoadSaver) Save(ctx context.Context, addr []byte, data []byte) error {
+	return d.inner.Save(ctx, addr, data)
+}
@@ -0,0 +1,185 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ethersphere/feeds/lookup"
+)
+
+// manyFeeds publishes one update to n distinct feeds sharing signer and
+// h's LoadSaver, returning the feeds in publish order.
+func manyFeeds(t testing.TB, h *Handler, signer Signer, n int) []Feed {
+	t.Helper()
+
+	feeds := make([]Feed, n)
+	for i := 0; i < n; i++ {
+		topic, err := NewTopic(fmt.Sprintf("feed-%d", i), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request := NewFirstRequest(topic)
+		request.SetData(generateData(uint64(i)))
+		if err := request.Sign(signer); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := h.Update(context.Background(), request); err != nil {
+			t.Fatal(err)
+		}
+		feeds[i] = request.Feed
+	}
+	return feeds
+}
+
+func TestLookupBatch(t *testing.T) {
+	timeProvider := &fakeTimeProvider{currentTime: startTime.Time}
+	signer := newAliceSigner()
+
+	fh, _, teardownTest, err := setupTest(timeProvider, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardownTest()
+
+	ls := newMockLoadSaver()
+	fh.SetLoadSaver(ls)
+
+	const n = 10
+	feeds := manyFeeds(t, fh.Handler, signer, n)
+
+	qs := make([]*Query, n+1)
+	for i, f := range feeds {
+		f := f
+		qs[i] = NewQueryLatest(&f, lookup.NoClue)
+	}
+	// one query for a feed nobody ever published to: it must fail
+	// without affecting any of the others.
+	neverPublished, err := NewTopic("nobody published here", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	missingFeed := Feed{Topic: neverPublished, User: feeds[0].User}
+	qs[n] = NewQueryLatest(&missingFeed, lookup.NoClue)
+
+	results, errs := fh.LookupBatch(context.Background(), qs)
+	if len(results) != len(qs) || len(errs) != len(qs) {
+		t.Fatalf("expected %d results and errors, got %d and %d", len(qs), len(results), len(errs))
+	}
+
+	for i := 0; i < n; i++ {
+		if errs[i] != nil {
+			t.Fatalf("query %d: unexpected error: %v", i, errs[i])
+		}
+		if !bytes.Equal(results[i].Data(), generateData(uint64(i))) {
+			t.Fatalf("query %d: got %q, want %q", i, results[i].Data(), generateData(uint64(i)))
+		}
+	}
+	if errs[n] == nil {
+		t.Fatal("expected the query for an unpublished feed to fail")
+	}
+	if results[n] != nil {
+		t.Fatalf("expected no result for the unpublished feed, got %v", results[n])
+	}
+}
+
+func TestLookupBatchCancel(t *testing.T) {
+	timeProvider := &fakeTimeProvider{currentTime: startTime.Time}
+	signer := newAliceSigner()
+
+	fh, _, teardownTest, err := setupTest(timeProvider, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardownTest()
+
+	ls := newMockLoadSaver()
+	fh.SetLoadSaver(ls)
+
+	feeds := manyFeeds(t, fh.Handler, signer, 4)
+	qs := make([]*Query, len(feeds))
+	for i, f := range feeds {
+		f := f
+		qs[i] = NewQueryLatest(&f, lookup.NoClue)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, errs := fh.LookupBatch(ctx, qs)
+	for i := range qs {
+		if errs[i] != context.Canceled {
+			t.Fatalf("query %d: expected context.Canceled, got %v", i, errs[i])
+		}
+		if results[i] != nil {
+			t.Fatalf("query %d: expected no result once ctx is cancelled, got %v", i, results[i])
+		}
+	}
+}
+
+func benchmarkQueries(b *testing.B, n int) (*Handler, []*Query) {
+	b.Helper()
+
+	TimestampProvider = &fakeTimeProvider{currentTime: startTime.Time}
+	signer := newAliceSigner()
+
+	h := NewHandler(&HandlerParams{})
+	h.SetLoadSaver(newMockLoadSaver())
+
+	feeds := manyFeeds(b, h, signer, n)
+	qs := make([]*Query, n)
+	for i, f := range feeds {
+		f := f
+		qs[i] = NewQueryLatest(&f, lookup.NoClue)
+	}
+	return h, qs
+}
+
+// BenchmarkLookupSequential looks up 100 feeds one at a time, the way
+// callers had to before LookupBatch existed.
+func BenchmarkLookupSequential(b *testing.B) {
+	h, qs := benchmarkQueries(b, 100)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, q := range qs {
+			if _, err := h.Lookup(ctx, q); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkLookupBatch looks up the same 100 feeds through LookupBatch.
+func BenchmarkLookupBatch(b *testing.B) {
+	h, qs := benchmarkQueries(b, 100)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, errs := h.LookupBatch(ctx, qs)
+		for _, err := range errs {
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
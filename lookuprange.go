@@ -0,0 +1,160 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import (
+	"context"
+	"io"
+
+	"github.com/ethersphere/feeds/lookup"
+)
+
+// LookupRange returns every update published to f with a timestamp in
+// [from, to], sorted ascending. hint accelerates locating the update
+// active at to, the same way it would for a plain Query.
+//
+// It is built on top of RangeIter, which does the actual backward walk;
+// LookupRange just drains the iterator and reverses the result. Callers
+// expecting a large range should use RangeIter directly instead, so they
+// are not forced to hold every matching update in memory at once.
+func (h *Handler) LookupRange(ctx context.Context, f *Feed, from, to uint64, hint lookup.Epoch) ([]*Update, error) {
+	iter, err := h.RangeIter(ctx, f, from, to, hint)
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []*Update
+	for {
+		update, err := iter.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, update)
+	}
+
+	// the walk runs backward from to down to from, so reverse it into
+	// the ascending order callers expect.
+	for i, j := 0, len(updates)-1; i < j; i, j = i+1, j-1 {
+		updates[i], updates[j] = updates[j], updates[i]
+	}
+	return updates, nil
+}
+
+// RangeIter streams a feed's updates in descending timestamp order,
+// starting at the update active at to and walking back through its
+// history until the timestamp drops below from. It holds at most one
+// update in memory at a time, so LookupRange's buffer-then-reverse
+// approach is only one way to consume it - callers processing a long
+// history can call Next in a loop and never buffer the whole range.
+//
+// Each step prefers the fast path - the current update's own embedded
+// PrevEpoch hint (see Update.PrevEpoch) - but falls back to an ordinary
+// lookup.Lookup search for whatever update was active just before the
+// current one's timestamp when that hint is missing, such as for an
+// update published before the hint field existed, or one built by hand
+// instead of through Handler.NewRequest. That fallback costs what a plain
+// Lookup costs; it is never skipped, so the walk never drops an in-range
+// update just because a hint was absent.
+type RangeIter struct {
+	h    *Handler
+	ls   LoadSaver
+	feed *Feed
+	from uint64
+	next *Update
+	done bool
+}
+
+// RangeIter creates a RangeIter over f's updates in [from, to], seeded at
+// the update active at to (accelerated by hint, same as a plain Query).
+// A to with no matching update yields an iterator whose first Next call
+// returns io.EOF, not an error.
+func (h *Handler) RangeIter(ctx context.Context, f *Feed, from, to uint64, hint lookup.Epoch) (*RangeIter, error) {
+	ls, err := h.loadSaver()
+	if err != nil {
+		return nil, err
+	}
+
+	start, err := h.lookup(ctx, NewQuery(f, to, hint), ls)
+	if err != nil {
+		if isNotFound(err) {
+			return &RangeIter{done: true}, nil
+		}
+		return nil, err
+	}
+
+	return &RangeIter{
+		h:    h,
+		ls:   ls,
+		feed: f,
+		from: from,
+		next: start,
+	}, nil
+}
+
+// Next returns the next update in the walk, or io.EOF once the range is
+// exhausted.
+func (it *RangeIter) Next(ctx context.Context) (*Update, error) {
+	if it.done {
+		return nil, io.EOF
+	}
+
+	current := it.next
+	if current == nil || current.Timestamp < it.from {
+		it.done = true
+		return nil, io.EOF
+	}
+
+	prev, err := it.predecessor(ctx, current)
+	if err != nil {
+		return nil, err
+	}
+	it.next = prev
+	if it.next == nil {
+		it.done = true
+	}
+
+	return current, nil
+}
+
+// predecessor returns the update published immediately before current,
+// or nil if current is the feed's first update (or no earlier update can
+// be found at all).
+func (it *RangeIter) predecessor(ctx context.Context, current *Update) (*Update, error) {
+	if current.HasPrevEpoch {
+		addr := addressForEpoch(it.feed, current.PrevEpoch)
+		if raw, err := it.ls.Load(ctx, addr); err == nil {
+			if prev, err := parseUpdate(raw); err == nil {
+				return prev, nil
+			}
+		}
+	}
+
+	if current.Timestamp == 0 {
+		return nil, nil
+	}
+	prev, err := it.h.lookup(ctx, NewQuery(it.feed, current.Timestamp-1, lookup.NoClue), it.ls)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return prev, nil
+}
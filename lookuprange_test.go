@@ -0,0 +1,186 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ethersphere/feeds/lookup"
+)
+
+// TestLookupRange replays the blinky/pinky/inky/clyde update sequence
+// from TestFeedsHandler and checks that LookupRange returns only the
+// updates whose timestamp falls in the requested range, in ascending
+// order.
+func TestLookupRange(t *testing.T) {
+	clock := &fakeTimeProvider{currentTime: startTime.Time} // t=4200
+	signer := newAliceSigner()
+
+	feedsHandler, _, teardownTest, err := setupTest(clock, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardownTest()
+
+	ls := newMockLoadSaver()
+	feedsHandler.SetLoadSaver(ls)
+
+	ctx := context.Background()
+	topic, _ := NewTopic("Time travel through Swarm feeds", nil)
+	a, err := signer.EthereumAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd := Feed{Topic: topic}
+	copy(fd.User[:], a.Bytes())
+
+	updates := []string{
+		"blinky", // t=4200
+		"pinky",  // t=4242
+		"inky",   // t=4284
+		"clyde",  // t=4285
+	}
+	gaps := []uint64{0, 42, 42, 1}
+
+	request := NewFirstRequest(fd.Topic)
+	for i, data := range updates {
+		clock.FastForward(gaps[i])
+		if i > 0 {
+			request, err = feedsHandler.NewRequest(ctx, &request.Feed)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		request.SetData([]byte(data))
+		if err := request.Sign(signer); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := feedsHandler.Update(ctx, request); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := feedsHandler.LookupRange(ctx, &request.Feed, 4242, 4285, lookup.NoClue)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"pinky", "inky", "clyde"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d updates, got %d (%v)", len(want), len(got), got)
+	}
+	for i, update := range got {
+		if !bytes.Equal(update.Data(), []byte(want[i])) {
+			t.Fatalf("update %d: got %q, want %q", i, update.Data(), want[i])
+		}
+	}
+}
+
+// TestLookupRangeWithoutHints checks that LookupRange still finds every
+// in-range update when none of them carry a PrevEpoch hint - as if the
+// feed had been written before that field existed, or by hand-built
+// Requests instead of through Handler.NewRequest. The walk must fall
+// back to an ordinary lookup-based predecessor search instead of
+// stopping at the first unhinted update.
+func TestLookupRangeWithoutHints(t *testing.T) {
+	clock := &fakeTimeProvider{currentTime: startTime.Time}
+	signer := newAliceSigner()
+
+	feedsHandler, _, teardownTest, err := setupTest(clock, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardownTest()
+
+	feedsHandler.SetLoadSaver(newMockLoadSaver())
+
+	ctx := context.Background()
+	topic, _ := NewTopic("No hints here", nil)
+	a, err := signer.EthereumAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd := Feed{Topic: topic}
+	copy(fd.User[:], a.Bytes())
+
+	updates := []string{"bashful", "doc", "sleepy"}
+	times := []uint64{0, 10 * Year, 20 * Year}
+
+	var epoch lookup.Epoch
+	for i, data := range updates {
+		request := NewFirstRequest(fd.Topic)
+		request.Epoch = lookup.GetNextEpoch(epoch, times[i])
+		request.Timestamp = times[i]
+		// deliberately leave PrevEpoch/HasPrevEpoch unset, as an update
+		// published before chunk0-3 would be.
+		request.SetData([]byte(data))
+		if err := request.Sign(signer); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := feedsHandler.Update(ctx, request); err != nil {
+			t.Fatal(err)
+		}
+		epoch = request.Epoch
+	}
+
+	got, err := feedsHandler.LookupRange(ctx, &fd, 0, 20*Year, lookup.NoClue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(updates) {
+		t.Fatalf("expected %d updates, got %d (%v)", len(updates), len(got), got)
+	}
+	for i, update := range got {
+		if !bytes.Equal(update.Data(), []byte(updates[i])) {
+			t.Fatalf("update %d: got %q, want %q", i, update.Data(), updates[i])
+		}
+	}
+}
+
+// TestLookupRangeEmpty checks that a range matching nothing returns an
+// empty, non-error result instead of io.EOF leaking out as an error.
+func TestLookupRangeEmpty(t *testing.T) {
+	clock := &fakeTimeProvider{currentTime: startTime.Time}
+	signer := newAliceSigner()
+
+	feedsHandler, _, teardownTest, err := setupTest(clock, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardownTest()
+
+	feedsHandler.SetLoadSaver(newMockLoadSaver())
+
+	ctx := context.Background()
+	topic, _ := NewTopic("Nothing ever happened here", nil)
+	a, err := signer.EthereumAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd := Feed{Topic: topic}
+	copy(fd.User[:], a.Bytes())
+
+	got, err := feedsHandler.LookupRange(ctx, &fd, 0, 100, lookup.NoClue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected no updates for a feed nobody published to, got %d", len(got))
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import (
+	"context"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/ethersphere/feeds/lookup"
+	"github.com/ethersphere/feeds/manifest"
+)
+
+// ManifestMetadata is the descriptive information CreateManifest stores
+// alongside a feed's (User, Topic) pair, and ResolveManifest hands back
+// when reading it.
+type ManifestMetadata struct {
+	DisplayName string
+	ContentType string
+}
+
+// CreateManifest stores a small document binding f's (User, Topic) pair
+// to meta, under an address derived from the document's own content.
+// That address is the feed's "manifest reference": handing it to
+// ResolveManifest (on any Handler sharing the same LoadSaver) recovers f
+// without the caller needing to already know f.User.
+func (h *Handler) CreateManifest(ctx context.Context, f *Feed, meta *ManifestMetadata) ([]byte, error) {
+	ls, err := h.loadSaver()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &manifest.Metadata{
+		User:      f.User,
+		Topic:     f.Topic,
+		CreatedAt: TimestampProvider.Now().Time,
+	}
+	if meta != nil {
+		doc.DisplayName = meta.DisplayName
+		doc.ContentType = meta.ContentType
+	}
+
+	data, err := manifest.Encode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := manifestAddress(data)
+	if err := ls.Save(ctx, ref, data); err != nil {
+		return nil, err
+	}
+	return ref, nil
+}
+
+// ResolveManifest loads the manifest stored at ref - as returned by a
+// prior CreateManifest call - and returns the Feed and metadata it
+// describes.
+func (h *Handler) ResolveManifest(ctx context.Context, ref []byte) (*Feed, *ManifestMetadata, error) {
+	ls, err := h.loadSaver()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	raw, err := ls.Load(ctx, ref)
+	if err != nil {
+		return nil, nil, NewError(ErrNotFound, "no manifest found at this reference")
+	}
+	doc, err := manifest.Decode(raw)
+	if err != nil {
+		return nil, nil, NewError(ErrCorruptData, "manifest data is corrupt: "+err.Error())
+	}
+
+	f := &Feed{Topic: doc.Topic, User: doc.User}
+	meta := &ManifestMetadata{DisplayName: doc.DisplayName, ContentType: doc.ContentType}
+	return f, meta, nil
+}
+
+// NewQueryFromManifest resolves ref the way ResolveManifest does, then
+// returns a Query for the feed it names, equivalent to calling NewQuery
+// with that Feed directly. Handler.Lookup and LookupBatch also accept a
+// Query built with NewQueryRef instead, which defers resolving ref to
+// Lookup itself; NewQueryFromManifest remains useful when a caller wants
+// ref's validity (and the resolved Feed) reported immediately rather
+// than at Lookup time.
+func (h *Handler) NewQueryFromManifest(ctx context.Context, ref []byte, time uint64, hint lookup.Epoch) (*Query, error) {
+	f, _, err := h.ResolveManifest(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return NewQuery(f, time, hint), nil
+}
+
+// NewRequestFromManifest resolves ref the way ResolveManifest does, then
+// prepares the Request for the feed's next update, equivalent to calling
+// NewRequest with that Feed directly.
+//
+// There is no Update counterpart that accepts ref directly: Request.Sign
+// bakes request.Feed.Topic into the signed digest, and signing always
+// happens before Update is called, so resolving a manifest reference
+// inside Update itself would be too late - the stored chunk would carry
+// a Feed that no longer matches what was actually signed, and Validate
+// would reject it. Resolving ref into a concrete Feed before the caller
+// signs, as NewRequestFromManifest does, is the only sound point for the
+// write path.
+func (h *Handler) NewRequestFromManifest(ctx context.Context, ref []byte) (*Request, error) {
+	f, _, err := h.ResolveManifest(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return h.NewRequest(ctx, f)
+}
+
+// manifestAddress derives a manifest's storage address from its encoded
+// content, the same way an ordinary content-addressed chunk would be.
+func manifestAddress(data []byte) []byte {
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write(data)
+	return hasher.Sum(nil)
+}
@@ -0,0 +1,61 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package manifest implements a thin, chunk-backed document binding a
+// human-friendly feed reference (like "alice/news") to the (User, Topic)
+// pair that actually addresses it. A consumer who only has the manifest's
+// storage address - its "reference" - can resolve it back to the feed
+// without the owner needing to hand out their address out of band.
+//
+// This package only knows how to encode and decode that document; it has
+// no notion of LoadSaver or chunk addressing, since both of those belong
+// to the feed package that wraps it and would otherwise create an import
+// cycle.
+package manifest
+
+import "encoding/json"
+
+// UserLength is the size, in bytes, of the feed owner's address.
+const UserLength = 20
+
+// TopicLength is the size, in bytes, of a feed's topic.
+const TopicLength = 32
+
+// Metadata is the document stored at a feed manifest reference.
+type Metadata struct {
+	User  [UserLength]byte
+	Topic [TopicLength]byte
+
+	// CreatedAt is the manifest's own creation time, not the feed's.
+	CreatedAt uint64
+
+	DisplayName string
+	ContentType string
+}
+
+// Encode serializes m into the bytes stored at its manifest reference.
+func Encode(m *Metadata) ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// Decode parses data, as produced by Encode, back into a Metadata.
+func Decode(data []byte) (*Metadata, error) {
+	m := &Metadata{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
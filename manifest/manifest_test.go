@@ -0,0 +1,61 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package manifest
+
+import "testing"
+
+func TestEncodeDecode(t *testing.T) {
+	want := &Metadata{
+		CreatedAt:   1533799046,
+		DisplayName: "alice/news",
+		ContentType: "text/plain",
+	}
+	want.User[0] = 0xaa
+	want.Topic[0] = 0xbb
+
+	data, err := Encode(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.User != want.User {
+		t.Fatalf("User: got %x, want %x", got.User, want.User)
+	}
+	if got.Topic != want.Topic {
+		t.Fatalf("Topic: got %x, want %x", got.Topic, want.Topic)
+	}
+	if got.CreatedAt != want.CreatedAt {
+		t.Fatalf("CreatedAt: got %d, want %d", got.CreatedAt, want.CreatedAt)
+	}
+	if got.DisplayName != want.DisplayName {
+		t.Fatalf("DisplayName: got %q, want %q", got.DisplayName, want.DisplayName)
+	}
+	if got.ContentType != want.ContentType {
+		t.Fatalf("ContentType: got %q, want %q", got.ContentType, want.ContentType)
+	}
+}
+
+func TestDecodeCorrupt(t *testing.T) {
+	if _, err := Decode([]byte("not json")); err == nil {
+		t.Fatal("expected an error decoding corrupt manifest data")
+	}
+}
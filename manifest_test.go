@@ -0,0 +1,131 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ethersphere/feeds/lookup"
+)
+
+// TestManifest creates a manifest on one Handler, resolves it on a second
+// Handler sharing the same storage, and performs a Lookup through the
+// resolved feed.
+func TestManifest(t *testing.T) {
+	timeProvider := &fakeTimeProvider{currentTime: startTime.Time}
+	signer := newAliceSigner()
+
+	writer, _, teardownTest, err := setupTest(timeProvider, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardownTest()
+
+	ls := newMockLoadSaver()
+	writer.SetLoadSaver(ls)
+
+	ctx := context.Background()
+	topic, err := NewTopic("alice/news", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := signer.EthereumAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd := Feed{Topic: topic}
+	copy(fd.User[:], a.Bytes())
+
+	request := NewFirstRequest(fd.Topic)
+	request.SetData([]byte("hello manifest"))
+	if err := request.Sign(signer); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Update(ctx, request); err != nil {
+		t.Fatal(err)
+	}
+
+	ref, err := writer.CreateManifest(ctx, &fd, &ManifestMetadata{
+		DisplayName: "alice/news",
+		ContentType: "text/plain",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, _, teardownReader, err := setupTest(timeProvider, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardownReader()
+	reader.SetLoadSaver(ls)
+
+	resolved, meta, err := reader.ResolveManifest(ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Topic != fd.Topic || resolved.User != fd.User {
+		t.Fatalf("resolved feed %v, want %v", resolved, fd)
+	}
+	if meta.DisplayName != "alice/news" || meta.ContentType != "text/plain" {
+		t.Fatalf("unexpected metadata: %+v", meta)
+	}
+
+	update, err := reader.Lookup(ctx, NewQueryLatest(resolved, lookup.NoClue))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(update.Data(), []byte("hello manifest")) {
+		t.Fatalf("lookup through resolved feed got %q, want %q", update.Data(), "hello manifest")
+	}
+
+	query, err := reader.NewQueryFromManifest(ctx, ref, 0, lookup.NoClue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.Feed.Topic != fd.Topic || query.Feed.User != fd.User {
+		t.Fatalf("NewQueryFromManifest built query for %v, want %v", query.Feed, fd)
+	}
+
+	// Lookup must also accept a Query that names its feed only by
+	// ManifestRef, without the caller ever calling ResolveManifest.
+	direct, err := reader.Lookup(ctx, NewQueryLatestRef(ref, lookup.NoClue))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(direct.Data(), []byte("hello manifest")) {
+		t.Fatalf("lookup through NewQueryLatestRef got %q, want %q", direct.Data(), "hello manifest")
+	}
+}
+
+func TestResolveManifestNotFound(t *testing.T) {
+	timeProvider := &fakeTimeProvider{currentTime: startTime.Time}
+	signer := newAliceSigner()
+
+	h, _, teardownTest, err := setupTest(timeProvider, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardownTest()
+	h.SetLoadSaver(newMockLoadSaver())
+
+	if _, _, err := h.ResolveManifest(context.Background(), []byte("no such reference")); err == nil {
+		t.Fatal("expected an error resolving a reference that was never created")
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import "github.com/ethersphere/feeds/lookup"
+
+// Query describes a lookup against a feed: find the update that was
+// active at TimeLimit (or, via NewQueryLatest, whichever update is most
+// recent), optionally accelerated with an epoch Hint.
+//
+// The feed being queried can be identified directly (Feed) or indirectly
+// (ManifestRef, a reference returned by Handler.CreateManifest); Handler
+// methods that take a Query resolve ManifestRef to a Feed themselves, so
+// callers who only have a manifest reference never need to call
+// ResolveManifest by hand. Exactly one of Feed and ManifestRef should be
+// set; use NewQuery/NewQueryLatest for the former and NewQueryRef for the
+// latter.
+type Query struct {
+	Feed        Feed
+	ManifestRef []byte
+	Hint        lookup.Epoch
+	TimeLimit   uint64
+}
+
+// NewQuery creates a Query for the update active at time, accelerated by
+// hint if one is known.
+func NewQuery(feed *Feed, time uint64, hint lookup.Epoch) *Query {
+	return &Query{
+		Feed:      *feed,
+		Hint:      hint,
+		TimeLimit: time,
+	}
+}
+
+// NewQueryLatest creates a Query for the most recent update of feed,
+// using the current time as its upper bound.
+func NewQueryLatest(feed *Feed, hint lookup.Epoch) *Query {
+	return NewQuery(feed, TimestampProvider.Now().Time, hint)
+}
+
+// NewQueryRef creates a Query for the update active at time, for the feed
+// named by ref - a manifest reference returned by Handler.CreateManifest
+// - accelerated by hint if one is known. The Handler resolving this
+// Query must share the LoadSaver the manifest was created with.
+func NewQueryRef(ref []byte, time uint64, hint lookup.Epoch) *Query {
+	return &Query{
+		ManifestRef: ref,
+		Hint:        hint,
+		TimeLimit:   time,
+	}
+}
+
+// NewQueryLatestRef is NewQueryRef, but for the feed's most recent
+// update, using the current time as its upper bound.
+func NewQueryLatestRef(ref []byte, hint lookup.Epoch) *Query {
+	return NewQueryRef(ref, TimestampProvider.Now().Time, hint)
+}
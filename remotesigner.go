@@ -0,0 +1,114 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+)
+
+// feedUpdateMimeType is the MIME type RemoteSigner tags every signing
+// request with, so a remote signer can apply feed-update-specific
+// policy (e.g. a Clef approval rule) instead of treating the digest as
+// an opaque transaction hash.
+const feedUpdateMimeType = "application/x-swarm-feed-update"
+
+// SignerTransport delivers a signing request to wherever the private
+// key actually lives.
+type SignerTransport interface {
+	// SignData asks account to sign data, tagged with mimeType so the
+	// remote side can apply content-aware approval policy, and returns
+	// the raw signature bytes.
+	SignData(ctx context.Context, account EthereumAddress, mimeType string, data []byte) ([]byte, error)
+}
+
+// RemoteSigner is a Signer whose private key never enters this process:
+// it routes every Sign call through a SignerTransport to an external
+// signer such as Clef, a hardware wallet, or a KMS.
+type RemoteSigner struct {
+	address   EthereumAddress
+	transport SignerTransport
+}
+
+// NewRemoteSigner creates a RemoteSigner for address, signing through
+// transport.
+func NewRemoteSigner(address EthereumAddress, transport SignerTransport) *RemoteSigner {
+	return &RemoteSigner{
+		address:   address,
+		transport: transport,
+	}
+}
+
+// Sign implements Signer by delegating to the transport.
+func (s *RemoteSigner) Sign(ctx context.Context, digest []byte) (signature Signature, err error) {
+	raw, err := s.transport.SignData(ctx, s.address, feedUpdateMimeType, digest)
+	if err != nil {
+		return signature, err
+	}
+	if len(raw) != SignatureLength {
+		return signature, NewErrorf(ErrInvalidSignature, "remote signer returned a %d-byte signature, expected %d", len(raw), SignatureLength)
+	}
+	copy(signature[:], raw)
+	return signature, nil
+}
+
+// EthereumAddress implements Signer.
+func (s *RemoteSigner) EthereumAddress() (EthereumAddress, error) {
+	return s.address, nil
+}
+
+// ClefRPCClient is the slice of an RPC client's surface ClefTransport
+// needs. *rpc.Client from go-ethereum satisfies it; it is spelled out
+// here so this package does not have to depend on go-ethereum's rpc
+// package just to describe the one call it makes.
+type ClefRPCClient interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+// ClefTransport is a SignerTransport that speaks Clef's account_signData
+// JSON-RPC method.
+type ClefTransport struct {
+	Client ClefRPCClient
+}
+
+// NewClefTransport creates a ClefTransport that issues requests over
+// client.
+func NewClefTransport(client ClefRPCClient) *ClefTransport {
+	return &ClefTransport{Client: client}
+}
+
+// SignData implements SignerTransport by calling Clef's account_signData
+// over JSON-RPC, hex-encoding the request and decoding the response the
+// way Clef expects.
+func (c *ClefTransport) SignData(ctx context.Context, account EthereumAddress, mimeType string, data []byte) ([]byte, error) {
+	var result string
+	err := c.Client.CallContext(ctx, &result, "account_signData",
+		mimeType,
+		"0x"+account.Hex(),
+		"0x"+hex.EncodeToString(data),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := hex.DecodeString(strings.TrimPrefix(result, "0x"))
+	if err != nil {
+		return nil, NewErrorf(ErrInvalidSignature, "could not decode Clef response: %v", err)
+	}
+	return signature, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import (
+	"context"
+	"testing"
+)
+
+// stubTransport is a SignerTransport backed by a GenericSigner, standing
+// in for a real Clef instance in tests.
+type stubTransport struct {
+	signer *GenericSigner
+}
+
+func (s *stubTransport) SignData(ctx context.Context, account EthereumAddress, mimeType string, data []byte) ([]byte, error) {
+	signature, err := s.signer.Sign(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return signature[:], nil
+}
+
+// TestValidatorRemoteSigner mirrors TestValidator, but signs through a
+// RemoteSigner instead of holding the private key directly.
+func TestValidatorRemoteSigner(t *testing.T) {
+	timeProvider := &fakeTimeProvider{
+		currentTime: startTime.Time,
+	}
+
+	localSigner := newAliceSigner()
+	address, err := localSigner.EthereumAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := NewRemoteSigner(address, &stubTransport{signer: localSigner})
+
+	rh, _, teardownTest, err := setupTest(timeProvider, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardownTest()
+
+	topic, _ := NewTopic(subtopicName, nil)
+	mr := NewFirstRequest(topic)
+
+	data := []byte("foo")
+	mr.SetData(data)
+	if err := mr.SignContext(context.Background(), signer); err != nil {
+		t.Fatalf("sign fail: %v", err)
+	}
+
+	addr, chunkData, err := mr.toChunk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rh.Validate(addr, chunkData) {
+		t.Fatal("Chunk validator fail on update chunk signed via RemoteSigner")
+	}
+
+	// mess with the address
+	badAddr := make([]byte, len(addr))
+	copy(badAddr, addr)
+	badAddr[0] = 11
+	badAddr[15] = 99
+	if rh.Validate(badAddr, chunkData) {
+		t.Fatal("Expected Validate to fail with false chunk address")
+	}
+}
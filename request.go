@@ -0,0 +1,106 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import (
+	"context"
+
+	"github.com/ethersphere/feeds/lookup"
+)
+
+// Request is an unsigned (or freshly signed) feed update in the process
+// of being published. Callers build one with NewFirstRequest or
+// Handler.NewRequest, fill in the content with SetData, Sign it, then
+// hand it to Handler.Update.
+type Request struct {
+	Feed Feed
+	Update
+	Signature *Signature
+}
+
+// NewFirstRequest creates the Request for a feed's very first update,
+// timestamped at the current time and published at the coarsest
+// possible epoch, since nothing is yet known about how often the feed
+// will be updated.
+func NewFirstRequest(topic Topic) *Request {
+	request := &Request{
+		Feed: Feed{Topic: topic},
+	}
+	now := TimestampProvider.Now().Time
+	request.Epoch = lookup.GetFirstEpoch(now)
+	request.Timestamp = now
+	return request
+}
+
+// Sign signs the request with signer, filling in both the resulting
+// Signature and the feed's User address (derived from the signer), so
+// callers never need to set Feed.User by hand.
+//
+// Sign has no context of its own to honor cancellation with; callers
+// that need to bound a remote signer's round trip should use SignContext
+// instead.
+func (r *Request) Sign(signer Signer) error {
+	return r.SignContext(context.Background(), signer)
+}
+
+// SignContext is Sign, but lets the caller bound the signing round trip
+// with ctx - useful when signer is a RemoteSigner talking to something
+// over the network.
+func (r *Request) SignContext(ctx context.Context, signer Signer) error {
+	address, err := signer.EthereumAddress()
+	if err != nil {
+		return err
+	}
+	r.Feed.User = address
+
+	digest := requestDigest(r.envelope(), r.data)
+	signature, err := signer.Sign(ctx, digest)
+	if err != nil {
+		return err
+	}
+	r.Signature = &signature
+	return nil
+}
+
+// envelope returns the chunkEnvelope describing this request, minus the
+// signature.
+func (r *Request) envelope() *chunkEnvelope {
+	return &chunkEnvelope{
+		Topic:        r.Feed.Topic,
+		Epoch:        r.Epoch,
+		Timestamp:    r.Timestamp,
+		PrevEpoch:    r.PrevEpoch,
+		HasPrevEpoch: r.HasPrevEpoch,
+	}
+}
+
+// toChunk assembles the request into its final on-disk form: an address
+// derived from (User, Topic, Epoch) and a payload carrying everything
+// needed to validate and read it back.
+func (r *Request) toChunk() (address []byte, data []byte, err error) {
+	if r.Signature == nil {
+		return nil, nil, NewError(ErrInvalidSignature, "request is not signed")
+	}
+
+	address = addressForEpoch(&r.Feed, r.Epoch)
+
+	env := r.envelope()
+	env.Signature = *r.Signature
+	data = encodeChunk(env, r.data)
+
+	return address, data, nil
+}
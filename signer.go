@@ -0,0 +1,116 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/btcec"
+	"golang.org/x/crypto/sha3"
+)
+
+// EthereumAddressLength is the size, in bytes, of an Ethereum address.
+const EthereumAddressLength = 20
+
+// EthereumAddress is a standalone copy of go-ethereum's common.Address,
+// kept local so that this package does not have to pull in go-ethereum
+// proper just to describe "who owns this feed".
+type EthereumAddress [EthereumAddressLength]byte
+
+// Bytes returns the address as a byte slice.
+func (a EthereumAddress) Bytes() []byte {
+	return a[:]
+}
+
+// Hex returns the hex-encoded address, without a leading "0x".
+func (a EthereumAddress) Hex() string {
+	return hex.EncodeToString(a[:])
+}
+
+// SignatureLength is the size, in bytes, of a Signature: a 64-byte
+// secp256k1 signature plus a 1-byte recovery id.
+const SignatureLength = 65
+
+// Signature is a recoverable secp256k1 signature over a feed update
+// chunk's digest.
+type Signature [SignatureLength]byte
+
+// Signer signs feed update chunks on behalf of a feed's owner. The
+// implementation decides where the private material lives: GenericSigner
+// keeps it in process, while other implementations may delegate to an
+// external signer that never exposes the key.
+type Signer interface {
+	// Sign signs digest and returns the resulting signature. ctx lets
+	// implementations that round-trip to an external signer (a Clef
+	// instance, a hardware wallet, a KMS) honor cancellation and
+	// timeouts; in-process signers may ignore it.
+	Sign(ctx context.Context, digest []byte) (Signature, error)
+	// EthereumAddress returns the address that Sign's signatures
+	// recover to.
+	EthereumAddress() (EthereumAddress, error)
+}
+
+// GenericSigner signs with a private key held directly in memory. It is
+// the default Signer for callers who manage their own keys.
+type GenericSigner struct {
+	PrivKey *ecdsa.PrivateKey
+	address EthereumAddress
+}
+
+// NewGenericSigner creates a GenericSigner from an ECDSA private key.
+func NewGenericSigner(privKey *ecdsa.PrivateKey) *GenericSigner {
+	return &GenericSigner{
+		PrivKey: privKey,
+		address: ethereumAddress(&privKey.PublicKey),
+	}
+}
+
+// Sign implements Signer. It signs locally and so never blocks on
+// anything ctx could usefully cancel.
+func (s *GenericSigner) Sign(ctx context.Context, digest []byte) (signature Signature, err error) {
+	privKey := (*btcec.PrivateKey)(s.PrivKey)
+	sig, err := btcec.SignCompact(btcec.S256(), privKey, digest, false)
+	if err != nil {
+		return signature, err
+	}
+	// btcec.SignCompact returns [recovery id | r | s]; feed signatures
+	// are conventionally [r | s | recovery id], matching how Ethereum
+	// lays out its own recoverable signatures.
+	copy(signature[:], sig[1:])
+	signature[SignatureLength-1] = sig[0] - 27
+	return signature, nil
+}
+
+// EthereumAddress implements Signer.
+func (s *GenericSigner) EthereumAddress() (EthereumAddress, error) {
+	return s.address, nil
+}
+
+// ethereumAddress derives the Ethereum address for a public key: the
+// last 20 bytes of the Keccak256 hash of its uncompressed encoding
+// (minus the leading 0x04 prefix byte).
+func ethereumAddress(pub *ecdsa.PublicKey) EthereumAddress {
+	var address EthereumAddress
+	pubBytes := (*btcec.PublicKey)(pub).SerializeUncompressed()
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write(pubBytes[1:])
+	hash := hasher.Sum(nil)
+	copy(address[:], hash[len(hash)-EthereumAddressLength:])
+	return address
+}
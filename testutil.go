@@ -0,0 +1,31 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+// TestHandler wraps a Handler for use in tests: it accepts a datadir
+// for parity with how a production handler would be wired up, even
+// though tests immediately replace storage with an in-memory LoadSaver.
+type TestHandler struct {
+	*Handler
+}
+
+// NewTestHandler creates a TestHandler backed by a fresh Handler. It
+// never fails today, but returns an error to leave room for datadir-based
+// setup (e.g. a persistent cache) without breaking callers.
+func NewTestHandler(datadir string, params *HandlerParams) (*TestHandler, error) {
+	return &TestHandler{Handler: NewHandler(params)}, nil
+}
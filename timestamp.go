@@ -0,0 +1,50 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import "time"
+
+// Timestamp wraps a Unix time, in seconds. It exists as a distinct type
+// rather than a bare uint64 so that feed code never accidentally mixes
+// update timestamps with other numeric fields (epoch bases, levels...).
+type Timestamp struct {
+	Time uint64
+}
+
+// timestampProvider is satisfied by anything that can report "now" as a
+// Timestamp. Production code uses DefaultTimestampProvider; tests swap in
+// a fake clock so that update sequences can be driven deterministically.
+//
+// Implementations must be safe for concurrent use: Watch calls Now() from
+// its own background goroutine, so a fake clock that's also advanced from
+// the test goroutine needs its own synchronization.
+type timestampProvider interface {
+	Now() Timestamp
+}
+
+// TimestampProvider is the source of truth for "now" throughout the feed
+// package. It defaults to wall-clock time and is only ever overridden in
+// tests.
+var TimestampProvider timestampProvider = &DefaultTimestampProvider{}
+
+// DefaultTimestampProvider reports the real wall-clock time.
+type DefaultTimestampProvider struct{}
+
+// Now returns the current Unix time.
+func (*DefaultTimestampProvider) Now() Timestamp {
+	return Timestamp{Time: uint64(time.Now().Unix())}
+}
@@ -0,0 +1,65 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import (
+	"encoding/hex"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// TopicLength is the size, in bytes, of a Topic.
+const TopicLength = 32
+
+// Topic identifies a feed independently of its owner: two different users
+// can each publish their own updates under the same Topic, and consumers
+// tell them apart by (User, Topic) pairs, not Topic alone.
+type Topic [TopicLength]byte
+
+// NewTopic creates a Topic from a human-readable name plus optional
+// related content (for example the address of a piece of content the
+// feed discusses). A name longer than TopicLength is hashed so that it
+// fits in the fixed-size Topic; the name's bytes are then XORed with the
+// hash of relatedContent.
+func NewTopic(name string, relatedContent []byte) (Topic, error) {
+	var topic Topic
+	hasher := sha3.NewLegacyKeccak256()
+	if _, err := hasher.Write(relatedContent); err != nil {
+		return topic, err
+	}
+	hashedContent := hasher.Sum(nil)
+	hasher.Reset()
+	nameBytes := []byte(name)
+	if len(nameBytes) > TopicLength {
+		if _, err := hasher.Write(nameBytes); err != nil {
+			return topic, err
+		}
+		nameBytes = hasher.Sum(nil)
+	}
+	copy(topic[:], nameBytes)
+	for i := range topic {
+		if i < len(hashedContent) {
+			topic[i] ^= hashedContent[i]
+		}
+	}
+	return topic, nil
+}
+
+// Hex returns the hex-encoded representation of the topic.
+func (t Topic) Hex() string {
+	return hex.EncodeToString(t[:])
+}
@@ -0,0 +1,70 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import (
+	"encoding/binary"
+
+	"github.com/ethersphere/feeds/lookup"
+)
+
+// epochByteLength is the size, in bytes, of a serialized lookup.Epoch:
+// the 8-byte Time plus a 1-byte level.
+const epochByteLength = 9
+
+// Update is the payload published at a feed epoch: the epoch itself
+// (embedded, so callers can write update.Level or update.Base() directly),
+// the wall-clock time it was created at, and the raw content.
+type Update struct {
+	lookup.Epoch
+	Timestamp uint64
+
+	// PrevEpoch is the epoch of the feed's previous update, if any. It
+	// is a hint only: HasPrevEpoch is false for a feed's first update
+	// and for any update published before this field existed.
+	PrevEpoch    lookup.Epoch
+	HasPrevEpoch bool
+
+	data []byte
+}
+
+// SetData sets the raw content this update carries.
+func (u *Update) SetData(data []byte) {
+	u.data = data
+}
+
+// Data returns the raw content this update carries.
+func (u *Update) Data() []byte {
+	return u.data
+}
+
+func encodeEpoch(e lookup.Epoch) []byte {
+	b := make([]byte, epochByteLength)
+	binary.BigEndian.PutUint64(b[:8], e.Time)
+	b[8] = e.Level
+	return b
+}
+
+func decodeEpoch(b []byte) (lookup.Epoch, error) {
+	if len(b) < epochByteLength {
+		return lookup.Epoch{}, NewError(ErrCorruptData, "epoch section truncated")
+	}
+	return lookup.Epoch{
+		Time:  binary.BigEndian.Uint64(b[:8]),
+		Level: b[8],
+	}, nil
+}
@@ -0,0 +1,163 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethersphere/feeds/lookup"
+)
+
+const (
+	// defaultWatchMinInterval is used when HandlerParams.WatchMinInterval
+	// is unset.
+	defaultWatchMinInterval = 500 * time.Millisecond
+	// defaultWatchMaxInterval is used when HandlerParams.WatchMaxInterval
+	// is unset.
+	defaultWatchMaxInterval = 5 * time.Minute
+)
+
+// Watch returns a channel that delivers every new update to the feed
+// identified by q (q.Feed, or q.ManifestRef resolved the way Lookup
+// resolves it), starting from the latest update known at the time Watch
+// is called, plus a channel carrying the one terminal error that ends the
+// watch (context cancellation, or a failure to resolve or look up the
+// feed).
+//
+// Watch re-polls the feed adaptively: a burst of updates shortens the
+// interval down to q's handler's WatchMinInterval, and silence backs it
+// off exponentially up to WatchMaxInterval. It de-duplicates by epoch,
+// so a re-poll landing on an already-seen update never re-emits it, and
+// it exits cleanly when ctx is done.
+func (h *Handler) Watch(ctx context.Context, q *Query) (<-chan *Update, <-chan error) {
+	updates := make(chan *Update)
+	errs := make(chan error, 1)
+
+	go h.watch(ctx, q, updates, errs)
+
+	return updates, errs
+}
+
+func (h *Handler) watch(ctx context.Context, q *Query, updates chan<- *Update, errs chan<- error) {
+	defer close(updates)
+	defer close(errs)
+
+	minInterval := h.watchMinInterval()
+	maxInterval := h.watchMaxInterval()
+
+	q, err := h.resolveQuery(ctx, q)
+	if err != nil {
+		errs <- err
+		return
+	}
+
+	feed := q.Feed
+	hint := q.Hint
+	interval := minInterval
+
+	var last lookup.Epoch
+	var haveLast bool
+
+	seed, err := h.Lookup(ctx, NewQueryLatest(&feed, hint))
+	switch {
+	case err == nil:
+		last, haveLast = seed.Epoch, true
+		hint = seed.Epoch
+		if !emit(ctx, updates, seed) {
+			errs <- ctx.Err()
+			return
+		}
+	case isNotFound(err):
+		// nothing published yet: keep polling at minInterval until
+		// the first update shows up.
+	default:
+		errs <- err
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			errs <- ctx.Err()
+			return
+		case <-time.After(interval):
+		}
+
+		update, err := h.Lookup(ctx, NewQueryLatest(&feed, hint))
+		switch {
+		case err == nil:
+			if haveLast && update.Epoch.Equals(last) {
+				interval = backoff(interval, maxInterval)
+				continue
+			}
+			if haveLast {
+				interval = gapHint(last, update.Epoch, minInterval, maxInterval)
+			} else {
+				interval = minInterval
+			}
+			last, haveLast = update.Epoch, true
+			hint = update.Epoch
+			if !emit(ctx, updates, update) {
+				errs <- ctx.Err()
+				return
+			}
+		case isNotFound(err):
+			interval = backoff(interval, maxInterval)
+		default:
+			errs <- err
+			return
+		}
+	}
+}
+
+func emit(ctx context.Context, updates chan<- *Update, update *Update) bool {
+	select {
+	case updates <- update:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// backoff doubles interval, capped at max.
+func backoff(interval, max time.Duration) time.Duration {
+	next := interval * 2
+	if next > max || next <= 0 {
+		return max
+	}
+	return next
+}
+
+// gapHint turns the time elapsed between two observed epochs into a
+// starting poll interval, clamped to [min, max]. It is only a hint: the
+// feed's actual cadence may differ, and subsequent quiet or busy polls
+// will adjust it further via backoff.
+func gapHint(previous, current lookup.Epoch, min, max time.Duration) time.Duration {
+	currentBase, previousBase := current.Base(), previous.Base()
+	if currentBase <= previousBase {
+		return min
+	}
+	hint := time.Duration(currentBase-previousBase) * time.Second
+	if hint < min {
+		return min
+	}
+	if hint > max {
+		return max
+	}
+	return hint
+}
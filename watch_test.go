@@ -0,0 +1,136 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethersphere/feeds/lookup"
+)
+
+// watchFakeTimeProvider is a fakeTimeProvider that's safe to advance from
+// the test goroutine while Watch's background goroutine is reading it:
+// plain fakeTimeProvider has no synchronization of its own, which is fine
+// for tests that only ever call Now() and FastForward() from the same
+// goroutine, but Watch breaks that assumption.
+type watchFakeTimeProvider struct {
+	mu          sync.Mutex
+	currentTime uint64
+}
+
+func (f *watchFakeTimeProvider) Now() Timestamp {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return Timestamp{Time: f.currentTime}
+}
+
+func (f *watchFakeTimeProvider) FastForward(offset uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.currentTime += offset
+}
+
+// TestHandlerWatch mirrors TestFeedsHandler, but reads the four updates
+// back through Watch instead of polling Lookup by hand.
+func TestHandlerWatch(t *testing.T) {
+	clock := &watchFakeTimeProvider{
+		currentTime: startTime.Time, // t=4200
+	}
+	signer := newAliceSigner()
+
+	feedsHandler, _, teardownTest, err := setupTest(clock, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardownTest()
+
+	feedsHandler.params.WatchMinInterval = time.Millisecond
+	feedsHandler.params.WatchMaxInterval = 10 * time.Millisecond
+
+	feedsHandler.SetLoadSaver(newMockLoadSaver())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	topic, _ := NewTopic("watch me", nil)
+
+	// publish the first update directly, the way NewFirstRequest expects.
+	first := NewFirstRequest(topic)
+	first.SetData([]byte("blinky"))
+	if err := first.Sign(signer); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := feedsHandler.Update(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+
+	updates, errs := feedsHandler.Watch(ctx, NewQueryLatest(&first.Feed, lookup.NoClue))
+
+	want := []string{"blinky", "pinky", "inky", "clyde"}
+	if got := expectUpdate(t, updates, errs); got != want[0] {
+		t.Fatalf("got update %q, want %q", got, want[0])
+	}
+
+	gaps := []uint64{21, 42, 1}
+	for i, data := range want[1:] {
+		clock.FastForward(gaps[i])
+		next, err := feedsHandler.NewRequest(ctx, &first.Feed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		next.SetData([]byte(data))
+		if err := next.Sign(signer); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := feedsHandler.Update(ctx, next); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := expectUpdate(t, updates, errs); got != data {
+			t.Fatalf("got update %q, want %q", got, data)
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-errs:
+		if err != context.Canceled {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for Watch to exit after cancel")
+	}
+}
+
+func expectUpdate(t *testing.T, updates <-chan *Update, errs <-chan error) string {
+	t.Helper()
+	select {
+	case update, ok := <-updates:
+		if !ok {
+			t.Fatal("updates channel closed unexpectedly")
+		}
+		return string(update.data)
+	case err := <-errs:
+		t.Fatalf("Watch exited early: %v", err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for update")
+	}
+	return ""
+}